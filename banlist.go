@@ -0,0 +1,178 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// banTrackerBuckets is the number of time buckets denyCounter splits its
+// sliding window into. Denials are bucketed rather than timestamped
+// individually so recording one is an O(1), allocation-free operation.
+const banTrackerBuckets = 6
+
+// denyCounter is a fixed-size, time-bucketed ring of deny counts for one
+// client IP.
+type denyCounter struct {
+	mu      sync.Mutex
+	buckets [banTrackerBuckets]int
+	epoch   int64 // bucket index currently at buckets[epoch%banTrackerBuckets]
+}
+
+// record rotates out buckets that have aged out of the window and adds one
+// denial to the current bucket, returning the total across all buckets
+// still within the window.
+func (c *denyCounter) record(now time.Time, bucketWidth time.Duration) int {
+	bucket := now.UnixNano() / int64(bucketWidth)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch shift := bucket - c.epoch; {
+	case shift <= 0:
+		// Same bucket (or clock skew); nothing to rotate.
+	case shift >= banTrackerBuckets:
+		c.buckets = [banTrackerBuckets]int{}
+	default:
+		for i := int64(1); i <= shift; i++ {
+			c.buckets[(c.epoch+i)%banTrackerBuckets] = 0
+		}
+	}
+	c.epoch = bucket
+	c.buckets[bucket%banTrackerBuckets]++
+
+	total := 0
+	for _, n := range c.buckets {
+		total += n
+	}
+	return total
+}
+
+// banTracker implements the adaptive rate limiting behind
+// --web.deny-threshold/--web.deny-window/--web.ban-duration: a client that
+// racks up more than threshold denied requests inside window is banned for
+// banDuration. Only ipRestrictMiddleware's deny path calls RecordDenial, so
+// an IP matching a static allow entry is never tracked or banned. Tracked
+// entries are capped at maxTracked via LRU eviction so a flood of spoofed
+// source IPs can't grow this map without bound.
+type banTracker struct {
+	counters sync.Map // ip string -> *denyCounter, read-heavy so kept lock-free
+
+	threshold   int
+	bucketWidth time.Duration
+	banDuration time.Duration
+	maxTracked  int
+
+	// mu guards the LRU and ban expiry bookkeeping below; it's only ever
+	// held for map/list operations, never across the per-IP deny counting.
+	mu          sync.Mutex
+	lru         *list.List
+	lruElements map[string]*list.Element
+	bannedUntil map[string]time.Time
+
+	bannedClients prometheus.Gauge
+	bansTotal     prometheus.Counter
+}
+
+// newBanTracker creates a banTracker and registers its metrics on reg.
+// threshold <= 0 should be treated by the caller as "disabled". window is
+// clamped so bucketWidth is never zero: an operator passing
+// --web.deny-window shorter than banTrackerBuckets nanoseconds would
+// otherwise make denyCounter.record divide by zero on the first denial.
+func newBanTracker(threshold int, window, banDuration time.Duration, maxTracked int, reg prometheus.Registerer) *banTracker {
+	bucketWidth := window / banTrackerBuckets
+	if bucketWidth <= 0 {
+		bucketWidth = 1
+	}
+	bt := &banTracker{
+		threshold:   threshold,
+		bucketWidth: bucketWidth,
+		banDuration: banDuration,
+		maxTracked:  maxTracked,
+		lru:         list.New(),
+		lruElements: make(map[string]*list.Element),
+		bannedUntil: make(map[string]time.Time),
+		bannedClients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "node_exporter_banned_clients",
+			Help: "Current number of client IPs temporarily banned for exceeding the deny-rate threshold.",
+		}),
+		bansTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "node_exporter_bans_total",
+			Help: "Total number of times a client IP has been banned for exceeding the deny-rate threshold.",
+		}),
+	}
+	reg.MustRegister(bt.bannedClients, bt.bansTotal)
+	return bt
+}
+
+// Banned reports whether ip is currently serving out a ban, lazily
+// expiring it if the ban has elapsed. Expiry only removes the ban entry;
+// ip's deny counter and LRU entry are kept.
+func (bt *banTracker) Banned(ip string) bool {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	until, ok := bt.bannedUntil[ip]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(bt.bannedUntil, ip)
+		bt.bannedClients.Dec()
+		return false
+	}
+	return true
+}
+
+// RecordDenial records a denied request for ip and bans it once the deny
+// threshold is exceeded within the window. Callers must only invoke this
+// for requests that have already failed the allowlist check.
+func (bt *banTracker) RecordDenial(ip string) {
+	v, _ := bt.counters.LoadOrStore(ip, &denyCounter{})
+	count := v.(*denyCounter).record(time.Now(), bt.bucketWidth)
+	bt.touch(ip)
+
+	if count <= bt.threshold {
+		return
+	}
+
+	bt.mu.Lock()
+	_, alreadyBanned := bt.bannedUntil[ip]
+	bt.bannedUntil[ip] = time.Now().Add(bt.banDuration)
+	bt.mu.Unlock()
+
+	if !alreadyBanned {
+		bt.bansTotal.Inc()
+		bt.bannedClients.Inc()
+	}
+}
+
+// touch marks ip as most-recently-used and evicts the least-recently-used
+// tracked IP(s) once more than maxTracked distinct IPs are being watched.
+func (bt *banTracker) touch(ip string) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if el, ok := bt.lruElements[ip]; ok {
+		bt.lru.MoveToFront(el)
+	} else {
+		bt.lruElements[ip] = bt.lru.PushFront(ip)
+	}
+
+	for bt.maxTracked > 0 && bt.lru.Len() > bt.maxTracked {
+		oldest := bt.lru.Back()
+		if oldest == nil {
+			break
+		}
+		oldIP := oldest.Value.(string)
+		bt.lru.Remove(oldest)
+		delete(bt.lruElements, oldIP)
+		bt.counters.Delete(oldIP)
+		if _, banned := bt.bannedUntil[oldIP]; banned {
+			delete(bt.bannedUntil, oldIP)
+			bt.bannedClients.Dec()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package collector contains the Collector interface and the machinery
+// that turns a set of registered factories into the single prometheus.Collector
+// the exporter serves on --web.telemetry-path.
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "node"
+
+var (
+	scrapeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
+		"node_exporter: Duration of a collector scrape.",
+		[]string{"collector"},
+		nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_success"),
+		"node_exporter: Whether a collector succeeded.",
+		[]string{"collector"},
+		nil,
+	)
+)
+
+// Collector is the interface every collector, built-in or out-of-tree, has
+// to implement. Register is the supported way to add one.
+type Collector interface {
+	// Update gets new metrics and exposes them via ch.
+	Update(ch chan<- prometheus.Metric) error
+}
+
+// NodeCollector implements prometheus.Collector by fanning a scrape out to
+// every enabled Collector concurrently and reporting each one's own
+// duration/success as node_scrape_collector_*.
+type NodeCollector struct {
+	Collectors map[string]Collector
+	logger     *slog.Logger
+}
+
+// NewNodeCollector builds a NodeCollector from the registered factories.
+// With no filters, every collector not turned off by
+// DisableDefaultCollectors is included. With filters (collect[]/exclude[]
+// on a single request), only the named collectors are instantiated,
+// regardless of their enabled/disabled state.
+func NewNodeCollector(logger *slog.Logger, filters ...string) (*NodeCollector, error) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+
+	want := make(map[string]bool, len(filters))
+	for _, f := range filters {
+		if _, ok := factories[f]; !ok {
+			return nil, fmt.Errorf("missing collector: %s", f)
+		}
+		want[f] = true
+	}
+
+	collectors := make(map[string]Collector, len(factories))
+	for name, f := range factories {
+		if len(want) > 0 {
+			if !want[name] {
+				continue
+			}
+		} else if disabled[name] {
+			continue
+		}
+		c, err := f(logger.With("collector", name))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create collector %s: %w", name, err)
+		}
+		collectors[name] = c
+	}
+	return &NodeCollector{Collectors: collectors, logger: logger}, nil
+}
+
+// Describe implements prometheus.Collector.
+func (n *NodeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+}
+
+// Collect implements prometheus.Collector, running every enabled collector
+// concurrently and reporting its outcome.
+func (n *NodeCollector) Collect(ch chan<- prometheus.Metric) {
+	wg := sync.WaitGroup{}
+	wg.Add(len(n.Collectors))
+	for name, c := range n.Collectors {
+		go func(name string, c Collector) {
+			defer wg.Done()
+			execute(name, c, ch, n.logger)
+		}(name, c)
+	}
+	wg.Wait()
+}
+
+func execute(name string, c Collector, ch chan<- prometheus.Metric, logger *slog.Logger) {
+	start := time.Now()
+	err := c.Update(ch)
+	duration := time.Since(start)
+
+	success := 1.0
+	if err != nil {
+		logger.Error("collector failed", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+		success = 0
+	} else {
+		logger.Debug("collector succeeded", "name", name, "duration_seconds", duration.Seconds())
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+}
@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// registryMtx guards factories, written during package init() (by built-in
+// collectors and, via Register, by out-of-tree ones), and disabled, written
+// by DisableDefaultCollectors. NewNodeCollector reads both, at startup and
+// for every filtered scrape.
+var (
+	registryMtx sync.Mutex
+	factories   = make(map[string]func(logger *slog.Logger) (Collector, error))
+	// disabled holds collectors turned off by DisableDefaultCollectors.
+	disabled = make(map[string]bool)
+)
+
+// Register adds a collector factory under name, the stable extension point
+// for out-of-tree or private collectors: compile one in and call Register
+// from an init() func, the same way the built-in collectors register
+// themselves. Registering under a name that's already taken overwrites the
+// previous factory.
+func Register(name string, factory func(logger *slog.Logger) (Collector, error)) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	factories[name] = factory
+}
+
+// DisableDefaultCollectors turns off every registered collector, for
+// --collector.disable-defaults; specific ones can still be requested by
+// name via collect[] filters.
+func DisableDefaultCollectors() {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	for name := range factories {
+		disabled[name] = true
+	}
+}
@@ -1,16 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"os/user"
 	"runtime"
 	"slices"
 	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/prometheus/common/promslog"
 	"github.com/prometheus/common/promslog/flag"
@@ -20,10 +23,12 @@ import (
 	promcollectors "github.com/prometheus/client_golang/prometheus/collectors"
 	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/version"
 	"github.com/prometheus/exporter-toolkit/web"
 	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
 	"github.com/prometheus/node_exporter/collector"
+	"gopkg.in/yaml.v3"
 )
 
 // handler wraps an unfiltered http.Handler but uses a filtered handler,
@@ -39,19 +44,52 @@ type handler struct {
 	includeExporterMetrics  bool
 	maxRequests             int
 	logger                  *slog.Logger
+	requestsTotal           *prometheus.CounterVec
+	requestDuration         *prometheus.HistogramVec
+	collectorsFiltered      *prometheus.CounterVec
+	// alwaysServedRegistry holds metrics that stay visible even with
+	// --web.disable-exporter-metrics, which hides everything registered on
+	// exporterMetricsRegistry (including the ban, config reload and push
+	// metrics). ipRestrictMetrics' allow/deny totals are registered here.
+	alwaysServedRegistry *prometheus.Registry
+	// gatherer is the unfiltered prometheus.Gatherer backing
+	// unfilteredHandler, exposed for push mode (see push.go).
+	gatherer prometheus.Gatherer
+}
+
+// Gatherer returns the unfiltered prometheus.Gatherer used to serve
+// --web.telemetry-path, for reuse by push mode.
+func (h *handler) Gatherer() prometheus.Gatherer {
+	return h.gatherer
 }
 
 func newHandler(includeExporterMetrics bool, maxRequests int, logger *slog.Logger) *handler {
 	h := &handler{
 		exporterMetricsRegistry: prometheus.NewRegistry(),
+		alwaysServedRegistry:    prometheus.NewRegistry(),
 		includeExporterMetrics:  includeExporterMetrics,
 		maxRequests:             maxRequests,
 		logger:                  logger,
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_exporter_http_requests_total",
+			Help: "Total number of scrape requests served, by HTTP status code.",
+		}, []string{"code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "node_exporter_http_request_duration_seconds",
+			Help: "Histogram of scrape request durations.",
+		}, []string{"code"}),
+		collectorsFiltered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_exporter_scrape_collectors_filtered_total",
+			Help: "Total number of scrapes narrowed by a per-client-IP collector ACL, by matching CIDR.",
+		}, []string{"client_cidr"}),
 	}
 	if h.includeExporterMetrics {
 		h.exporterMetricsRegistry.MustRegister(
 			promcollectors.NewProcessCollector(promcollectors.ProcessCollectorOpts{}),
 			promcollectors.NewGoCollector(),
+			h.requestsTotal,
+			h.requestDuration,
+			h.collectorsFiltered,
 		)
 	}
 	if innerHandler, err := h.innerHandler(); err != nil {
@@ -70,12 +108,6 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	excludes := r.URL.Query()["exclude[]"]
 	h.logger.Debug("exclude query:", "excludes", excludes)
 
-	if len(collects) == 0 && len(excludes) == 0 {
-		// No filters, use the prepared unfiltered handler.
-		h.unfilteredHandler.ServeHTTP(w, r)
-		return
-	}
-
 	if len(collects) > 0 && len(excludes) > 0 {
 		h.logger.Debug("rejecting combined collect and exclude queries")
 		w.WriteHeader(http.StatusBadRequest)
@@ -83,6 +115,24 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if acl, ok := collectorACLFromContext(r.Context()); ok {
+		h.collectorsFiltered.WithLabelValues(acl.cidr).Inc()
+		filtered, denied := applyCollectorACL(h.enabledCollectors, acl.allowed, collects, excludes)
+		if denied {
+			h.logger.Debug("no collectors allowed for client, denying", "client_cidr", acl.cidr)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("No collectors allowed for this client."))
+			return
+		}
+		collects, excludes = filtered, nil
+	}
+
+	if len(collects) == 0 && len(excludes) == 0 {
+		// No filters, use the prepared unfiltered handler.
+		h.unfilteredHandler.ServeHTTP(w, r)
+		return
+	}
+
 	filters := &collects
 	if len(excludes) > 0 {
 		// In exclude mode, filtered collectors = enabled - excludeed.
@@ -136,10 +186,16 @@ func (h *handler) innerHandler(filters ...string) (http.Handler, error) {
 		return nil, fmt.Errorf("couldn't register node collector: %s", err)
 	}
 
+	if len(filters) == 0 {
+		// Keep a handle on the unfiltered gatherer so push mode can reuse
+		// it without spinning up a second node collector.
+		h.gatherer = prometheus.Gatherers{h.exporterMetricsRegistry, h.alwaysServedRegistry, r}
+	}
+
 	var handler http.Handler
 	if h.includeExporterMetrics {
 		handler = promhttp.HandlerFor(
-			prometheus.Gatherers{h.exporterMetricsRegistry, r},
+			prometheus.Gatherers{h.exporterMetricsRegistry, h.alwaysServedRegistry, r},
 			promhttp.HandlerOpts{
 				ErrorLog:            slog.NewLogLogger(h.logger.Handler(), slog.LevelError),
 				ErrorHandling:       promhttp.ContinueOnError,
@@ -154,7 +210,7 @@ func (h *handler) innerHandler(filters ...string) (http.Handler, error) {
 		)
 	} else {
 		handler = promhttp.HandlerFor(
-			r,
+			prometheus.Gatherers{h.alwaysServedRegistry, r},
 			promhttp.HandlerOpts{
 				ErrorLog:            slog.NewLogLogger(h.logger.Handler(), slog.LevelError),
 				ErrorHandling:       promhttp.ContinueOnError,
@@ -163,70 +219,294 @@ func (h *handler) innerHandler(filters ...string) (http.Handler, error) {
 		)
 	}
 
+	if h.includeExporterMetrics {
+		handler = promhttp.InstrumentHandlerDuration(h.requestDuration, handler)
+		handler = promhttp.InstrumentHandlerCounter(h.requestsTotal, handler)
+	}
+
 	return handler, nil
 }
 
-// ipRestrictMiddleware creates a middleware that checks if the client's IP
-// is in the allowlist before serving the request
-func ipRestrictMiddleware(next http.Handler, allowedIPs []string, logger *slog.Logger) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// If no IPs are specified, allow all access
-		if len(allowedIPs) == 0 {
-			next.ServeHTTP(w, r)
-			return
+// parseCIDRs parses a list of IP/CIDR strings into net.IPNet values,
+// skipping (and logging) any entries that fail to parse.
+func parseCIDRs(cidrs []string, logger *slog.Logger) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, c := range cidrs {
+		_, ipNet, err := net.ParseCIDR(c)
+		if err != nil {
+			// Allow bare IPs by treating them as /32 (or /128) CIDRs.
+			if ip := net.ParseIP(c); ip != nil {
+				bits := 32
+				if ip.To4() == nil {
+					bits = 128
+				}
+				ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			} else {
+				logger.Warn("Invalid CIDR, ignoring", "cidr", c, "err", err)
+				continue
+			}
 		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
 
-		// Get client IP from request
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			// If cannot parse address, use the RemoteAddr directly
-			ip = r.RemoteAddr
+// ipInNets reports whether ip is contained in any of nets.
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Check if client IP is in the allowlist
-		allowed := false
-		for _, allowedIP := range allowedIPs {
-			// Check for exact IP match or CIDR match
-			if allowedIP == ip {
-				allowed = true
-				break
+// clientIPFromRequest derives the real client IP for r. If trustForwardedFor
+// is false, or the immediate peer (r.RemoteAddr) is not within
+// trustedProxies, the peer address is returned unchanged. Otherwise it walks
+// the X-Forwarded-For chain from right (closest hop) to left, skipping
+// entries that are themselves trusted proxies, and returns the first
+// untrusted (i.e. real client) address it finds. Falls back to X-Real-IP,
+// then to the peer address, if X-Forwarded-For yields nothing usable.
+func clientIPFromRequest(r *http.Request, trustForwardedFor bool, trustedProxies []*net.IPNet, logger *slog.Logger) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+
+	if !trustForwardedFor {
+		return peer
+	}
+
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil || !ipInNets(peerIP, trustedProxies) {
+		// The immediate peer is not a trusted proxy; forwarded headers
+		// could be spoofed by the client, so ignore them.
+		return peer
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		for i := len(parts) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(parts[i])
+			hopIP := net.ParseIP(hop)
+			if hopIP == nil {
+				continue
+			}
+			if ipInNets(hopIP, trustedProxies) {
+				// Still within the trusted proxy chain, keep walking left.
+				continue
 			}
+			return hop
+		}
+	}
+
+	if xRealIP := r.Header.Get("X-Real-IP"); xRealIP != "" {
+		if ip := net.ParseIP(xRealIP); ip != nil {
+			return xRealIP
+		}
+	}
+
+	logger.Debug("Trusted proxy peer sent no usable forwarded-for header, falling back to peer address", "peer", peer)
+	return peer
+}
+
+// denyAction controls how ipRestrictMiddleware responds to a request from a
+// client that isn't on the allowlist.
+type denyAction string
+
+const (
+	// denyActionForbidden returns a standard 403 Forbidden response.
+	denyActionForbidden denyAction = "forbidden"
+	// denyActionClose silently closes the underlying TCP connection.
+	denyActionClose denyAction = "close"
+)
+
+// parseDenyAction validates a --web.deny-action flag value.
+func parseDenyAction(s string) (denyAction, error) {
+	switch denyAction(s) {
+	case denyActionForbidden, denyActionClose:
+		return denyAction(s), nil
+	default:
+		return "", fmt.Errorf("invalid deny action %q, must be one of: forbidden, close", s)
+	}
+}
+
+// ipRestrictMetrics holds the Prometheus metrics exported by
+// ipRestrictMiddleware. Callers share one instance across all middleware
+// wrapping a given server so allow/deny counts aren't split per-mux-pattern.
+type ipRestrictMetrics struct {
+	requestsAllowed prometheus.Counter
+	requestsDenied  *prometheus.CounterVec
+}
+
+// newIPRestrictMetrics creates and registers the ipRestrictMiddleware
+// metrics on reg.
+func newIPRestrictMetrics(reg prometheus.Registerer) *ipRestrictMetrics {
+	m := &ipRestrictMetrics{
+		requestsAllowed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "node_exporter_http_requests_allowed_total",
+			Help: "Total number of HTTP requests allowed by the IP allowlist.",
+		}),
+		requestsDenied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_exporter_http_requests_denied_total",
+			Help: "Total number of HTTP requests denied by the IP allowlist, by reason.",
+		}, []string{"reason"}),
+	}
+	reg.MustRegister(m.requestsAllowed, m.requestsDenied)
+	return m
+}
 
-			// Try to parse as CIDR
-			if strings.Contains(allowedIP, "/") {
-				_, ipNet, err := net.ParseCIDR(allowedIP)
-				if err == nil {
-					clientIP := net.ParseIP(ip)
-					if clientIP != nil && ipNet.Contains(clientIP) {
-						allowed = true
-						break
-					}
+// ipAllowed reports whether ip matches any entry of allowedIPs, each of
+// which may be an exact IP or a CIDR range.
+func ipAllowed(ip string, allowedIPs []string) bool {
+	for _, allowedIP := range allowedIPs {
+		if allowedIP == ip {
+			return true
+		}
+		if strings.Contains(allowedIP, "/") {
+			_, ipNet, err := net.ParseCIDR(allowedIP)
+			if err == nil {
+				clientIP := net.ParseIP(ip)
+				if clientIP != nil && ipNet.Contains(clientIP) {
+					return true
 				}
 			}
 		}
+	}
+	return false
+}
 
-		if allowed {
-			logger.Debug("Access allowed", "ip", ip)
+// applyCollectorACL narrows a request's collect[]/exclude[] query down to
+// the intersection with allowed (a per-client-IP collector ACL). denied is
+// true when that intersection is empty, meaning the client has no
+// collectors it's permitted to scrape.
+func applyCollectorACL(enabledCollectors []string, allowed map[string]struct{}, collects, excludes []string) (filtered []string, denied bool) {
+	var requested []string
+	switch {
+	case len(collects) > 0:
+		requested = collects
+	case len(excludes) > 0:
+		for _, c := range enabledCollectors {
+			if slices.Index(excludes, c) == -1 {
+				requested = append(requested, c)
+			}
+		}
+	default:
+		requested = enabledCollectors
+	}
+
+	for _, c := range requested {
+		if _, ok := allowed[c]; ok {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, len(filtered) == 0
+}
+
+// allowedIPsForPath returns the allowlist that applies to path: the
+// allowlist of the first matching entry in cfg.pathRules, or cfg.allowedIPs
+// if none match.
+func allowedIPsForPath(cfg *resolvedAccessConfig, path string) []string {
+	for _, rule := range cfg.pathRules {
+		if rule.path == path {
+			return rule.allowedIPs
+		}
+	}
+	return cfg.allowedIPs
+}
+
+// hijackAndClose closes the underlying TCP connection without writing a
+// response, used by denyActionClose. Falls back to a 500 if the
+// ResponseWriter doesn't support hijacking.
+func hijackAndClose(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Webserver doesn't support hijacking", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	conn.Close()
+}
+
+// ipRestrictMiddleware creates a middleware that checks if the client's IP
+// is in the allowlist that applies to the request's path (see
+// allowedIPsForPath) before serving the request. getConfig is consulted on
+// every request, so callers backed by a hot-reloadable accessConfig pick up
+// changes without restarting. When a config's trustForwardedFor is enabled,
+// the client IP used for the allowlist check is derived from
+// X-Forwarded-For/X-Real-IP, but only when the immediate peer is itself
+// within its trustedProxyNets. Requests that are not allowed are handled
+// according to action. bans is optional (nil disables auto-ban entirely): a
+// client that has been temporarily banned for exceeding the deny-rate
+// threshold is rejected, unless it's statically allowed on this path, so a
+// ban picked up on one path_rule can never block a path the IP is allowed on.
+func ipRestrictMiddleware(next http.Handler, getConfig func() *resolvedAccessConfig, action denyAction, metrics *ipRestrictMetrics, bans *banTracker, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := getConfig()
+		var allowedIPs []string
+		if cfg != nil {
+			allowedIPs = allowedIPsForPath(cfg, r.URL.Path)
+		}
+
+		// No allowlist applies to this path: every client passes the IP
+		// check, but a configured collector_acl (multi-tenant filtering
+		// without a blanket allowlist) must still be evaluated and attached.
+		if len(allowedIPs) == 0 {
+			if cfg != nil && len(cfg.collectorACL) > 0 {
+				ip := clientIPFromRequest(r, cfg.trustForwardedFor, cfg.trustedProxyNets, logger)
+				if acl, ok := collectorsForIP(cfg, ip); ok {
+					r = r.WithContext(withCollectorACL(r.Context(), acl))
+				}
+			}
 			next.ServeHTTP(w, r)
-		} else {
-			logger.Warn("Access denied", "ip", ip)
+			return
+		}
+
+		ip := clientIPFromRequest(r, cfg.trustForwardedFor, cfg.trustedProxyNets, logger)
+		allowed := ipAllowed(ip, allowedIPs)
 
-			hj, ok := w.(http.Hijacker)
-			if !ok {
-				http.Error(w, "Webserver doesn't support hijacking", http.StatusInternalServerError)
-				return
+		// The ban check must never override a static allow entry: an IP
+		// that's allowed on this path (even if path_rules ban it elsewhere)
+		// is let through regardless of any ban racked up on a narrower path.
+		if !allowed && bans != nil && bans.Banned(ip) {
+			logger.Debug("Access denied, client is banned", "ip", ip)
+			metrics.requestsDenied.WithLabelValues("banned").Inc()
+			if action == denyActionClose {
+				hijackAndClose(w)
+			} else {
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			}
+			return
+		}
 
-			// 获取底层连接
-			conn, _, err := hj.Hijack()
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+		if allowed {
+			logger.Debug("Access allowed", "ip", ip)
+			metrics.requestsAllowed.Inc()
+			if acl, ok := collectorsForIP(cfg, ip); ok {
+				r = r.WithContext(withCollectorACL(r.Context(), acl))
 			}
-			defer conn.Close()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logger.Warn("Access denied", "ip", ip)
+		metrics.requestsDenied.WithLabelValues("ip_not_allowed").Inc()
+		if bans != nil {
+			// Only ever recorded for IPs that just failed the allowlist
+			// check above, so a statically allowed IP can never be banned.
+			bans.RecordDenial(ip)
+		}
 
-			// 直接关闭连接
-			conn.Close()
+		switch action {
+		case denyActionClose:
+			hijackAndClose(w)
+		default:
+			http.Error(w, "Forbidden", http.StatusForbidden)
 		}
 	})
 }
@@ -256,6 +536,50 @@ func main() {
 			"web.allow-ips",
 			"Comma-separated list of IP addresses or CIDR ranges allowed to access the exporter. Empty means allow all.",
 		).Default("").String()
+		trustForwardedFor = kingpin.Flag(
+			"web.trust-forwarded-for",
+			"Trust X-Forwarded-For/X-Real-IP headers from trusted proxies (see --web.trusted-proxies) when deriving the client IP for --web.allow-ips.",
+		).Default("false").Bool()
+		trustedProxies = kingpin.Flag(
+			"web.trusted-proxies",
+			"Comma-separated list of IP addresses or CIDR ranges of proxies allowed to set X-Forwarded-For/X-Real-IP. Only used when --web.trust-forwarded-for is set.",
+		).Default("").String()
+		webDenyAction = kingpin.Flag(
+			"web.deny-action",
+			"How to respond to requests denied by --web.allow-ips: \"forbidden\" (403 response) or \"close\" (silently close the connection).",
+		).Default("forbidden").String()
+		accessConfigFile = kingpin.Flag(
+			"web.access-config.file",
+			"Path to a YAML file with a hot-reloadable IP allowlist/trusted-proxies configuration. When set, it takes over from --web.allow-ips, --web.trust-forwarded-for and --web.trusted-proxies, and is reloaded on SIGHUP or POST /-/reload.",
+		).Default("").String()
+		pushRemoteWriteURL = kingpin.Flag(
+			"push.remote-write-url",
+			"If set, periodically push gathered metrics to this Prometheus remote_write endpoint instead of serving --web.telemetry-path; --web.telemetry-path and the landing page are not registered in this mode.",
+		).Default("").String()
+		pushInterval = kingpin.Flag(
+			"push.interval",
+			"Interval between remote_write pushes. Only used with --push.remote-write-url.",
+		).Default("15s").Duration()
+		pushHTTPClientConfigFile = kingpin.Flag(
+			"push.http-client-config.file",
+			"Path to a YAML file with HTTP client config (basic_auth/authorization) for --push.remote-write-url.",
+		).Default("").String()
+		denyThreshold = kingpin.Flag(
+			"web.deny-threshold",
+			"Ban a client IP after this many denied requests within --web.deny-window. 0 disables auto-ban.",
+		).Default("0").Int()
+		denyWindow = kingpin.Flag(
+			"web.deny-window",
+			"Sliding window over which --web.deny-threshold denied requests are counted.",
+		).Default("1m").Duration()
+		banDuration = kingpin.Flag(
+			"web.ban-duration",
+			"How long a client IP stays banned after exceeding --web.deny-threshold.",
+		).Default("10m").Duration()
+		banTrackedMaxEntries = kingpin.Flag(
+			"web.deny-tracking-max-entries",
+			"Maximum number of distinct client IPs tracked for auto-ban at once; least-recently-denied entries are evicted beyond this.",
+		).Default("10000").Int()
 		toolkitFlags = kingpinflag.AddFlags(kingpin.CommandLine, ":9100")
 	)
 
@@ -290,29 +614,113 @@ func main() {
 		logger.Info("IP restriction disabled, all IPs allowed")
 	}
 
+	var trustedProxyList []string
+	if *trustedProxies != "" {
+		trustedProxyList = strings.Split(*trustedProxies, ",")
+		for i, p := range trustedProxyList {
+			trustedProxyList[i] = strings.TrimSpace(p)
+		}
+	}
+	if *trustForwardedFor {
+		logger.Info("Trusting X-Forwarded-For/X-Real-IP from trusted proxies", "trusted_proxies", trustedProxyList)
+	}
+
+	denyAct, err := parseDenyAction(*webDenyAction)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	// Set up the handlers with IP restriction middleware
 	metricsHandler := newHandler(!*disableExporterMetrics, *maxRequests, logger)
-	http.Handle(*metricsPath, ipRestrictMiddleware(metricsHandler, allowlist, logger))
-
-	if *metricsPath != "/" {
-		landingConfig := web.LandingConfig{
-			Name:        "Node Exporter",
-			Description: "Prometheus Node Exporter",
-			Version:     version.Info(),
-			Links: []web.LandingLinks{
-				{
-					Address: *metricsPath,
-					Text:    "Metrics",
+	// Registered on alwaysServedRegistry, not exporterMetricsRegistry, so
+	// operators can still alert on denial rates with
+	// --web.disable-exporter-metrics set.
+	restrictMetrics := newIPRestrictMetrics(metricsHandler.alwaysServedRegistry)
+
+	var bans *banTracker
+	if *denyThreshold > 0 {
+		bans = newBanTracker(*denyThreshold, *denyWindow, *banDuration, *banTrackedMaxEntries, metricsHandler.exporterMetricsRegistry)
+		logger.Info("Auto-ban enabled", "threshold", *denyThreshold, "window", *denyWindow, "ban_duration", *banDuration)
+	}
+
+	var getAccessConfig func() *resolvedAccessConfig
+	if *accessConfigFile != "" {
+		ac := newAccessConfig(*accessConfigFile, metricsHandler.exporterMetricsRegistry, logger)
+		if err := ac.Reload(); err != nil {
+			logger.Error("Error loading access config file", "file", *accessConfigFile, "err", err)
+			os.Exit(1)
+		}
+		getAccessConfig = ac.Get
+		logger.Info("Using hot-reloadable access config file, --web.allow-ips and related flags are ignored", "file", *accessConfigFile)
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				ac.Reload()
+			}
+		}()
+		// Guard /-/reload with the same IP allowlist as /metrics and "/";
+		// exporter-toolkit's web config (TLS/basic-auth), if configured,
+		// applies on top of this, not instead of it.
+		http.Handle("/-/reload", ipRestrictMiddleware(ac.ReloadHandler(), ac.Get, denyAct, restrictMetrics, bans, logger))
+	} else {
+		getAccessConfig = staticAccessConfig(allowlist, *trustForwardedFor, trustedProxyList, logger)
+	}
+
+	// --push.remote-write-url replaces scraping: Prometheus can't reach an
+	// egress-only exporter to scrape it, so there's no point exposing
+	// --web.telemetry-path (or a landing page pointing at it) in that mode.
+	if *pushRemoteWriteURL == "" {
+		http.Handle(*metricsPath, ipRestrictMiddleware(metricsHandler, getAccessConfig, denyAct, restrictMetrics, bans, logger))
+
+		if *metricsPath != "/" {
+			landingConfig := web.LandingConfig{
+				Name:        "Node Exporter",
+				Description: "Prometheus Node Exporter",
+				Version:     version.Info(),
+				Links: []web.LandingLinks{
+					{
+						Address: *metricsPath,
+						Text:    "Metrics",
+					},
 				},
-			},
+			}
+			landingPage, err := web.NewLandingPage(landingConfig)
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+			// Apply IP restriction to the landing page too
+			http.Handle("/", ipRestrictMiddleware(landingPage, getAccessConfig, denyAct, restrictMetrics, bans, logger))
+		}
+	}
+
+	if *pushRemoteWriteURL != "" {
+		httpClientConfig := config.DefaultHTTPClientConfig
+		if *pushHTTPClientConfigFile != "" {
+			data, err := os.ReadFile(*pushHTTPClientConfigFile)
+			if err != nil {
+				logger.Error("Error reading push HTTP client config file", "file", *pushHTTPClientConfigFile, "err", err)
+				os.Exit(1)
+			}
+			if err := yaml.Unmarshal(data, &httpClientConfig); err != nil {
+				logger.Error("Error parsing push HTTP client config file", "file", *pushHTTPClientConfigFile, "err", err)
+				os.Exit(1)
+			}
 		}
-		landingPage, err := web.NewLandingPage(landingConfig)
+		pushClient, err := config.NewClientFromConfig(httpClientConfig, "node_exporter_push")
 		if err != nil {
-			logger.Error(err.Error())
+			logger.Error("Error creating push HTTP client", "err", err)
 			os.Exit(1)
 		}
-		// Apply IP restriction to the landing page too
-		http.Handle("/", ipRestrictMiddleware(landingPage, allowlist, logger))
+
+		p := newPusher(metricsHandler.Gatherer(), *pushRemoteWriteURL, *pushInterval, pushClient, metricsHandler.exporterMetricsRegistry, logger)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go p.Run(ctx)
+		logger.Info("Pushing metrics via remote_write", "url", *pushRemoteWriteURL, "interval", *pushInterval)
 	}
 
 	server := &http.Server{}
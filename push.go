@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// pusher periodically gathers metrics from a prometheus.Gatherer and pushes
+// them to a Prometheus remote_write endpoint, for deployments where
+// Prometheus can't reach this exporter to scrape it directly (IoT gateways,
+// NAT'd edge nodes, egress-only networks).
+type pusher struct {
+	gatherer prometheus.Gatherer
+	url      string
+	interval time.Duration
+	client   *http.Client
+	logger   *slog.Logger
+
+	pushesTotal  *prometheus.CounterVec
+	pushDuration prometheus.Histogram
+}
+
+// newPusher creates a pusher and registers its metrics on reg.
+func newPusher(gatherer prometheus.Gatherer, url string, interval time.Duration, client *http.Client, reg prometheus.Registerer, logger *slog.Logger) *pusher {
+	p := &pusher{
+		gatherer: gatherer,
+		url:      url,
+		interval: interval,
+		client:   client,
+		logger:   logger,
+		pushesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_exporter_push_total",
+			Help: "Total number of remote_write pushes, by outcome.",
+		}, []string{"outcome"}),
+		pushDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "node_exporter_push_duration_seconds",
+			Help: "Duration of remote_write pushes.",
+		}),
+	}
+	reg.MustRegister(p.pushesTotal, p.pushDuration)
+	return p
+}
+
+// Run gathers and pushes on p.interval until ctx is canceled.
+func (p *pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.pushOnce(ctx); err != nil {
+				p.logger.Error("Remote write push failed", "url", p.url, "err", err)
+			}
+		}
+	}
+}
+
+// pushOnce gathers the current metrics and POSTs them as a single
+// remote_write request, retrying on 5xx responses with exponential backoff.
+func (p *pusher) pushOnce(ctx context.Context) error {
+	start := time.Now()
+	defer func() { p.pushDuration.Observe(time.Since(start).Seconds()) }()
+
+	mfs, err := p.gatherer.Gather()
+	if err != nil {
+		p.pushesTotal.WithLabelValues("gather_error").Inc()
+		return fmt.Errorf("gathering metrics: %w", err)
+	}
+
+	data, err := proto.Marshal(metricFamiliesToWriteRequest(mfs, start))
+	if err != nil {
+		p.pushesTotal.WithLabelValues("encode_error").Inc()
+		return fmt.Errorf("marshaling write request: %w", err)
+	}
+	compressed := snappy.Encode(nil, data)
+
+	backoff := time.Second
+	const maxAttempts = 5
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(compressed))
+		if err != nil {
+			p.pushesTotal.WithLabelValues("request_error").Inc()
+			return fmt.Errorf("building request: %w", err)
+		}
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			p.pushesTotal.WithLabelValues("send_error").Inc()
+			return fmt.Errorf("sending request: %w", err)
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode/100 == 2:
+			p.pushesTotal.WithLabelValues("success").Inc()
+			return nil
+		case resp.StatusCode/100 == 5:
+			if attempt == maxAttempts {
+				break
+			}
+			p.logger.Warn("Remote write endpoint returned server error, retrying", "status", resp.StatusCode, "attempt", attempt)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		default:
+			p.pushesTotal.WithLabelValues("rejected").Inc()
+			return fmt.Errorf("remote write endpoint returned status %d", resp.StatusCode)
+		}
+	}
+
+	p.pushesTotal.WithLabelValues("exhausted_retries").Inc()
+	return fmt.Errorf("giving up after %d attempts against %s", maxAttempts, p.url)
+}
+
+// metricFamiliesToWriteRequest converts gathered metric families into a
+// remote_write WriteRequest, stamping every sample with ts.
+func metricFamiliesToWriteRequest(mfs []*dto.MetricFamily, ts time.Time) *prompb.WriteRequest {
+	tsMillis := ts.UnixMilli()
+	wr := &prompb.WriteRequest{}
+	for _, mf := range mfs {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			labels := metricLabels(name, m)
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(labels, m.GetCounter().GetValue(), tsMillis))
+			case dto.MetricType_GAUGE:
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(labels, m.GetGauge().GetValue(), tsMillis))
+			case dto.MetricType_UNTYPED:
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(labels, m.GetUntyped().GetValue(), tsMillis))
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					qLabels := withLabel(labels, "quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64))
+					wr.Timeseries = append(wr.Timeseries, newTimeseries(qLabels, q.GetValue(), tsMillis))
+				}
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(renameMetric(labels, name+"_sum"), s.GetSampleSum(), tsMillis))
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(renameMetric(labels, name+"_count"), float64(s.GetSampleCount()), tsMillis))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				bucketName := name + "_bucket"
+				for _, b := range h.GetBucket() {
+					bLabels := renameMetric(withLabel(labels, "le", strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)), bucketName)
+					wr.Timeseries = append(wr.Timeseries, newTimeseries(bLabels, float64(b.GetCumulativeCount()), tsMillis))
+				}
+				// The +Inf bucket is implicit in the exposition format (it
+				// always equals the total sample count) but remote_write
+				// consumers expect it as an explicit series.
+				infLabels := renameMetric(withLabel(labels, "le", "+Inf"), bucketName)
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(infLabels, float64(h.GetSampleCount()), tsMillis))
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(renameMetric(labels, name+"_sum"), h.GetSampleSum(), tsMillis))
+				wr.Timeseries = append(wr.Timeseries, newTimeseries(renameMetric(labels, name+"_count"), float64(h.GetSampleCount()), tsMillis))
+			}
+		}
+	}
+	return wr
+}
+
+// metricLabels builds the prompb labels for m, including __name__.
+func metricLabels(name string, m *dto.Metric) []prompb.Label {
+	labels := make([]prompb.Label, 0, len(m.GetLabel())+1)
+	labels = append(labels, prompb.Label{Name: "__name__", Value: name})
+	for _, lp := range m.GetLabel() {
+		labels = append(labels, prompb.Label{Name: lp.GetName(), Value: lp.GetValue()})
+	}
+	return labels
+}
+
+// withLabel returns a copy of labels with an additional name/value pair.
+func withLabel(labels []prompb.Label, name, value string) []prompb.Label {
+	out := make([]prompb.Label, len(labels), len(labels)+1)
+	copy(out, labels)
+	return append(out, prompb.Label{Name: name, Value: value})
+}
+
+// renameMetric returns a copy of labels with __name__ replaced by name.
+func renameMetric(labels []prompb.Label, name string) []prompb.Label {
+	out := make([]prompb.Label, len(labels))
+	copy(out, labels)
+	for i, l := range out {
+		if l.Name == "__name__" {
+			out[i].Value = name
+			break
+		}
+	}
+	return out
+}
+
+// newTimeseries builds a single-sample TimeSeries. labels are sorted by
+// name, as required by the remote_write spec (receivers such as
+// Prometheus/Mimir/Cortex/Thanos reject or mishandle unsorted series).
+func newTimeseries(labels []prompb.Label, value float64, tsMillis int64) prompb.TimeSeries {
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return prompb.TimeSeries{
+		Labels:  labels,
+		Samples: []prompb.Sample{{Value: value, Timestamp: tsMillis}},
+	}
+}